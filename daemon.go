@@ -0,0 +1,54 @@
+// +build linux
+
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	"github.com/opencontainers/runc/libcontainer/service"
+)
+
+const daemonUsage = `runc daemon serves the same container operations as the CLI (list,
+create, start, kill, delete, exec) plus a streaming events feed over a
+persistent gRPC connection on a Unix socket, so orchestrators don't have
+to fork runc per call.
+
+EXAMPLE:
+    # runc daemon --socket /run/runc.sock`
+
+// defaultSocketDir is where the daemon's Unix socket lives when --socket
+// isn't given. --root is itself an absolute state directory (default
+// /run/runc), so the default socket path is derived from its basename
+// rather than naively concatenated onto it.
+const defaultSocketDir = "/run/runc-daemon"
+
+var daemonCommand = cli.Command{
+	Name:  "daemon",
+	Usage: "run a gRPC daemon serving list and container operations over a Unix socket",
+	ArgsUsage: `[command options]
+
+Where "[command options]" is:
+
+    --socket <path>    Unix socket to listen on (default: /run/runc-daemon/<root basename>.sock)`,
+	Description: daemonUsage,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "socket",
+			Usage: "Unix socket to serve the gRPC API on",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		factory, err := loadFactory(context)
+		if err != nil {
+			return err
+		}
+		socketPath := context.String("socket")
+		if socketPath == "" {
+			root := filepath.Base(filepath.Clean(context.GlobalString("root")))
+			socketPath = filepath.Join(defaultSocketDir, root+".sock")
+		}
+		srv := service.NewServer(context.GlobalString("root"), factory)
+		return srv.Serve(socketPath)
+	},
+}
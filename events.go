@@ -0,0 +1,118 @@
+// +build linux
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/opencontainers/runc/libcontainer/service"
+)
+
+const eventsUsage = `display container events such as OOM notifications, create, start,
+pause, resume, exit, delete and periodic stats
+
+"exec" (running an additional process in an already-running container)
+is intentionally not reported here: it doesn't change the container's
+on-disk status, so this inotify-driven watcher can't observe it. The
+daemon's Events RPC (see "runc daemon") does emit "exec", since its
+Exec handler can post the event directly.
+
+EXAMPLE:
+To follow every lifecycle event for every container under the current root:
+
+    # runc events --all
+
+To follow a single container's events, formatted one JSON object per line:
+
+    # runc events --subject my-container --format jsonl`
+
+// event is the payload written to stdout for every state transition or
+// resource sample observed.
+type event struct {
+	Type      string      `json:"type"`
+	ID        string      `json:"id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+var eventsCommand = cli.Command{
+	Name:  "events",
+	Usage: "display container events such as OOM notifications, create, start and stats",
+	ArgsUsage: `[command options]
+
+Where "[command options]" is one of:
+
+    --subject <container-id>   only report events for the given container
+    --all                      report events for every container under root
+    --format json|jsonl        select how events are encoded on stdout`,
+	Description: eventsUsage,
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "subject", Usage: "only stream events for the given container id"},
+		cli.BoolFlag{Name: "all", Usage: "stream events for every container under root"},
+		cli.StringFlag{Name: "format", Value: "json", Usage: "select one of: json (pretty) or jsonl (one compact object per line)"},
+	},
+	Action: func(context *cli.Context) error {
+		subject := context.String("subject")
+		all := context.Bool("all")
+		if subject == "" && !all {
+			return fmt.Errorf("events: one of --subject or --all is required")
+		}
+
+		switch context.String("format") {
+		case "json", "jsonl":
+		default:
+			return fmt.Errorf("invalid format option")
+		}
+
+		factory, err := loadFactory(context)
+		if err != nil {
+			return err
+		}
+		root := context.GlobalString("root")
+
+		w, err := service.NewWatcher(root, factory, subject, all, 0)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+
+		stream := make(chan event, 128)
+		go func() {
+			w.Run(func(e service.WatchEvent) {
+				stream <- event{Type: e.Type, ID: e.ID, Timestamp: e.Timestamp, Data: e.Data}
+			})
+			close(stream)
+		}()
+
+		return writeEvents(os.Stdout, context.String("format"), stream)
+	},
+}
+
+// writeEvents drains stream to w, encoding each event according to
+// format: "jsonl" writes one compact object per line, "json" writes each
+// object indented so a human tailing the stream can read it directly.
+func writeEvents(w *os.File, format string, stream <-chan event) error {
+	if format == "jsonl" {
+		enc := json.NewEncoder(w)
+		for e := range stream {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for e := range stream {
+		b, err := json.MarshalIndent(e, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
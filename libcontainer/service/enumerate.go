@@ -0,0 +1,106 @@
+// +build linux
+
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// ContainerInfo is the platform agnostic summary of a running
+// container's status and state. It is the single source of truth for
+// container enumeration, shared by the `runc list` CLI command and the
+// daemon's ListContainers/GetContainer RPCs so the factory.Load loop
+// over the state root only lives in one place.
+type ContainerInfo struct {
+	ID             string
+	InitProcessPid int
+	Status         string
+	Bundle         string
+	Created        time.Time
+	Owner          string
+}
+
+// Enumerate walks root, the runc state directory, and loads every
+// container found there through factory, returning a ContainerInfo for
+// each.
+func Enumerate(factory libcontainer.Factory, root string) ([]ContainerInfo, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	list, err := ioutil.ReadDir(absRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []ContainerInfo
+	for _, item := range list {
+		if !item.IsDir() {
+			continue
+		}
+		container, err := factory.Load(item.Name())
+		if err != nil {
+			return nil, err
+		}
+		status, err := container.Status()
+		if err != nil {
+			return nil, err
+		}
+		state, err := container.State()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ContainerInfo{
+			ID:             state.BaseState.ID,
+			InitProcessPid: state.BaseState.InitProcessPid,
+			Status:         status.String(),
+			Bundle:         utils.SearchLabels(state.Config.Labels, "bundle"),
+			Created:        state.BaseState.Created,
+			Owner:          ownerOf(item, state),
+		})
+	}
+	return out, nil
+}
+
+// Load enumerates root looking for the single container identified by
+// id, returning an error if it isn't present.
+func Load(factory libcontainer.Factory, root, id string) (ContainerInfo, error) {
+	containers, err := Enumerate(factory, root)
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+	for _, c := range containers {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return ContainerInfo{}, fmt.Errorf("container %s does not exist", id)
+}
+
+// ownerOf attributes a container to a uid: the host-mapped root uid
+// inside the container's user namespace when uid mappings are actually
+// configured, or else the uid that owns the container's state directory
+// on the host. A userns with root mapped to host uid 0 (RootUID == 0)
+// still counts as "mapped" here, which a bare RootUID != 0 check would
+// have missed.
+func ownerOf(info os.FileInfo, state *libcontainer.State) string {
+	if len(state.Config.UidMappings) > 0 {
+		if uid, err := state.Config.HostUID(); err == nil {
+			return strconv.Itoa(uid)
+		}
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	return strconv.FormatUint(uint64(stat.Uid), 10)
+}
@@ -0,0 +1,282 @@
+// +build linux
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/specconv"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Server serves the runc daemon's gRPC API over a Unix socket:
+// ListContainers, GetContainer, Create, Start, Kill, Delete, Exec, and
+// the streaming Events RPC. It holds the same factory/root pair the CLI
+// commands use, with container enumeration delegated to Enumerate/Load
+// so that logic isn't duplicated between the two entry points.
+type Server struct {
+	Root    string
+	Factory libcontainer.Factory
+
+	bus *eventBus
+}
+
+// NewServer constructs a Server rooted at root, using factory to load
+// and manage containers.
+func NewServer(root string, factory libcontainer.Factory) *Server {
+	return &Server{Root: root, Factory: factory, bus: newEventBus()}
+}
+
+// Serve removes any stale socket at socketPath, listens there, and
+// blocks serving gRPC requests until the listener is closed. It also
+// starts a Watcher over every container under Root, so that Events
+// streams the same container-originated transitions (pause, oom, exit
+// from outside the daemon) that `runc events` reports, not just the
+// ops this server itself performed.
+func (s *Server) Serve(socketPath string) error {
+	if err := os.RemoveAll(socketPath); err != nil {
+		return err
+	}
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	w, err := NewWatcher(s.Root, s.Factory, "", true, 0)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	go w.Run(func(e WatchEvent) {
+		s.bus.publish(&Event{Type: e.Type, Id: e.ID, Timestamp: e.Timestamp.Unix()})
+	})
+
+	gs := grpc.NewServer()
+	RegisterRuncServer(gs, s)
+	return gs.Serve(l)
+}
+
+func (s *Server) ListContainers(ctx context.Context, req *ListContainersRequest) (*ListContainersResponse, error) {
+	infos, err := Enumerate(s.Factory, s.Root)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListContainersResponse{Containers: make([]*Container, len(infos))}
+	for i, info := range infos {
+		resp.Containers[i] = toProto(info)
+	}
+	return resp, nil
+}
+
+func (s *Server) GetContainer(ctx context.Context, req *GetContainerRequest) (*Container, error) {
+	info, err := Load(s.Factory, s.Root, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return toProto(info), nil
+}
+
+func (s *Server) Create(ctx context.Context, req *CreateRequest) (*Container, error) {
+	if req.Id == "" || req.Bundle == "" {
+		return nil, fmt.Errorf("service: Create requires id and bundle")
+	}
+	spec, err := loadSpec(req.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	config, err := specconv.CreateLibcontainerConfig(&specconv.CreateOpts{
+		CgroupName: req.Id,
+		Spec:       spec,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Factory.Create(req.Id, config); err != nil {
+		return nil, err
+	}
+	s.bus.publish(&Event{Type: "create", Id: req.Id, Timestamp: time.Now().UTC().Unix()})
+
+	info, err := Load(s.Factory, s.Root, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return toProto(info), nil
+}
+
+// loadSpec reads the OCI runtime spec out of bundle/config.json, the same
+// layout `runc create`/`runc run` expect.
+func loadSpec(bundle string) (*specs.Spec, error) {
+	f, err := os.Open(filepath.Join(bundle, "config.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var spec specs.Spec
+	if err := json.NewDecoder(f).Decode(&spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+func (s *Server) Start(ctx context.Context, req *StartRequest) (*Empty, error) {
+	container, err := s.Factory.Load(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := container.Exec(); err != nil {
+		return nil, err
+	}
+	s.bus.publish(&Event{Type: "start", Id: req.Id, Timestamp: time.Now().UTC().Unix()})
+	return &Empty{}, nil
+}
+
+func (s *Server) Kill(ctx context.Context, req *KillRequest) (*Empty, error) {
+	container, err := s.Factory.Load(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := container.Signal(syscall.Signal(req.Signal)); err != nil {
+		return nil, err
+	}
+	s.bus.publish(&Event{Type: "kill", Id: req.Id, Timestamp: time.Now().UTC().Unix()})
+	return &Empty{}, nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *DeleteRequest) (*Empty, error) {
+	container, err := s.Factory.Load(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := container.Destroy(); err != nil {
+		return nil, err
+	}
+	s.bus.publish(&Event{Type: "delete", Id: req.Id, Timestamp: time.Now().UTC().Unix()})
+	return &Empty{}, nil
+}
+
+// Exec runs an additional process inside an already-running container and
+// returns its pid without waiting for it to exit. The gRPC contract has no
+// stdio streaming, so the process inherits the daemon's own stdio rather
+// than the (remote) caller's.
+func (s *Server) Exec(ctx context.Context, req *ExecRequest) (*ExecResponse, error) {
+	if len(req.Args) == 0 {
+		return nil, fmt.Errorf("service: Exec requires at least one arg")
+	}
+	container, err := s.Factory.Load(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	process := &libcontainer.Process{
+		Args:   req.Args,
+		Env:    os.Environ(),
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	if err := container.Start(process); err != nil {
+		return nil, err
+	}
+	pid, err := process.Pid()
+	if err != nil {
+		return nil, err
+	}
+	s.bus.publish(&Event{Type: "exec", Id: req.Id, Timestamp: time.Now().UTC().Unix()})
+	return &ExecResponse{Pid: int32(pid)}, nil
+}
+
+// Events streams lifecycle events for req.Subject (or every container when
+// req.All is set) until the client disconnects, exactly as `runc events`
+// requires one of --subject or --all. The subscription is removed as soon
+// as the stream ends so a long-lived daemon doesn't accumulate dead
+// channels across reconnecting clients.
+func (s *Server) Events(req *EventsRequest, stream Runc_EventsServer) error {
+	if req.Subject == "" && !req.All {
+		return fmt.Errorf("service: Events requires Subject or All")
+	}
+	ch, unsubscribe := s.bus.subscribe()
+	defer unsubscribe()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case e, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if !req.All && e.Id != req.Subject {
+				continue
+			}
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProto(info ContainerInfo) *Container {
+	return &Container{
+		Id:      info.ID,
+		Pid:     int32(info.InitProcessPid),
+		Status:  info.Status,
+		Bundle:  info.Bundle,
+		Created: info.Created.Unix(),
+		Owner:   info.Owner,
+	}
+}
+
+// eventBus is the server's in-process publish/subscribe hub, fanning
+// every published event (the Create/Start/Kill/Delete/Exec notifications
+// and those the Watcher reports) out to every open Events stream.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers []chan *Event
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{}
+}
+
+// subscribe registers a new subscriber channel and returns an unsubscribe
+// func that removes and closes it; callers must invoke it once they stop
+// reading, or the channel leaks for the life of the daemon.
+func (b *eventBus) subscribe() (<-chan *Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan *Event, 128)
+	b.subscribers = append(b.subscribers, ch)
+	return ch, func() { b.unsubscribe(ch) }
+}
+
+func (b *eventBus) unsubscribe(ch chan *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, sub := range b.subscribers {
+		if sub == ch {
+			b.subscribers = append(b.subscribers[:i], b.subscribers[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+func (b *eventBus) publish(e *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
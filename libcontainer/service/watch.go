@@ -0,0 +1,512 @@
+// +build linux
+
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"golang.org/x/sys/unix"
+)
+
+// WatchEvent is a single lifecycle transition or periodic resource
+// sample observed by a Watcher.
+type WatchEvent struct {
+	Type      string
+	ID        string
+	Timestamp time.Time
+	Data      interface{}
+}
+
+// watchKind distinguishes the three things a Watcher puts an inotify
+// watch on.
+type watchKind int
+
+const (
+	watchRoot watchKind = iota
+	watchState
+	watchOOM
+)
+
+type watchTarget struct {
+	kind watchKind
+	id   string
+}
+
+// defaultStatsInterval is how often a Watcher samples cgroup usage for
+// every container it's tracking, independent of any state transition.
+const defaultStatsInterval = 5 * time.Second
+
+// Watcher turns the on-disk side effects of container lifecycle
+// transitions into WatchEvents: runc's factory rewrites each
+// container's state.json at create/start/pause/resume/exit and removes
+// it on delete; rather than polling for those changes, or reaching into
+// the factory/container internals, Watcher inotify-watches the state
+// root for directories appearing and disappearing, each container's
+// state.json for modifications, and each container's memory cgroup for
+// OOM notifications. It is shared by the `runc events` CLI command and
+// the daemon's Events RPC so both observe the same transitions instead
+// of the daemon only knowing about ops it performed itself.
+type Watcher struct {
+	root          string
+	factory       libcontainer.Factory
+	subject       string
+	all           bool
+	statsInterval time.Duration
+
+	in      *inotify
+	rootWD  int32
+	targets map[int32]watchTarget
+	stateWD map[string]int32
+	oomWD   map[string]int32
+
+	status   map[string]string
+	oomCount map[string]uint64
+
+	publish func(WatchEvent)
+}
+
+// NewWatcher starts watching root for subject's container lifecycle (or
+// every container's, when all is set), sampling cgroup usage for each
+// tracked container every statsInterval (a zero interval uses a
+// reasonable default).
+func NewWatcher(root string, factory libcontainer.Factory, subject string, all bool, statsInterval time.Duration) (*Watcher, error) {
+	if statsInterval <= 0 {
+		statsInterval = defaultStatsInterval
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	in, err := newInotify()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		root:          absRoot,
+		factory:       factory,
+		subject:       subject,
+		all:           all,
+		statsInterval: statsInterval,
+		in:            in,
+		targets:       make(map[int32]watchTarget),
+		stateWD:       make(map[string]int32),
+		oomWD:         make(map[string]int32),
+		status:        make(map[string]string),
+		oomCount:      make(map[string]uint64),
+	}
+
+	rootWD, err := in.add(absRoot, unix.IN_CREATE|unix.IN_DELETE|unix.IN_MOVED_FROM|unix.IN_MOVED_TO)
+	if err != nil {
+		in.close()
+		return nil, err
+	}
+	w.rootWD = rootWD
+	w.targets[rootWD] = watchTarget{kind: watchRoot}
+
+	entries, err := ioutil.ReadDir(absRoot)
+	if err != nil {
+		in.close()
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		// Seed status/watches for containers that already existed
+		// before the watcher started, without posting a synthetic
+		// "create" for them.
+		w.track(entry.Name(), false)
+	}
+
+	return w, nil
+}
+
+// Close stops the underlying inotify watch.
+func (w *Watcher) Close() error {
+	return w.in.close()
+}
+
+func (w *Watcher) reports(id string) bool {
+	return w.all || id == w.subject
+}
+
+// emit posts e through whichever publish func Run was handed, and is a
+// no-op before Run starts (used during the initial, silent seeding
+// pass).
+func (w *Watcher) emit(e WatchEvent) {
+	if w.publish != nil {
+		w.publish(e)
+	}
+}
+
+// track starts watching a container's state.json (and memory cgroup, if
+// it can be resolved) and records its current status. When announce is
+// true and the watcher is interested in id, a "create" event is posted.
+func (w *Watcher) track(id string, announce bool) {
+	container, err := w.factory.Load(id)
+	if err != nil {
+		return
+	}
+	status, err := container.Status()
+	if err != nil {
+		return
+	}
+	state, err := container.State()
+	if err != nil {
+		return
+	}
+	w.status[id] = status.String()
+
+	stateWD, err := w.in.add(filepath.Join(w.root, id, "state.json"), unix.IN_MODIFY|unix.IN_CLOSE_WRITE)
+	if err == nil {
+		w.stateWD[id] = stateWD
+		w.targets[stateWD] = watchTarget{kind: watchState, id: id}
+	}
+
+	if memoryPath := state.CgroupPaths["memory"]; memoryPath != "" {
+		for _, name := range []string{"memory.events", "memory.oom_control"} {
+			oomWD, err := w.in.add(filepath.Join(memoryPath, name), unix.IN_MODIFY)
+			if err != nil {
+				continue
+			}
+			w.oomWD[id] = oomWD
+			w.targets[oomWD] = watchTarget{kind: watchOOM, id: id}
+			break
+		}
+	}
+
+	if announce && w.reports(id) {
+		w.emit(WatchEvent{Type: "create", ID: id, Timestamp: time.Now().UTC()})
+	}
+}
+
+// untrack stops watching a removed container and posts its terminal
+// events: an "exit" if we never observed it stop cleanly, then
+// "delete".
+func (w *Watcher) untrack(id string) {
+	if wd, ok := w.stateWD[id]; ok {
+		w.in.remove(wd)
+		delete(w.targets, wd)
+		delete(w.stateWD, id)
+	}
+	if wd, ok := w.oomWD[id]; ok {
+		w.in.remove(wd)
+		delete(w.targets, wd)
+		delete(w.oomWD, id)
+	}
+
+	if w.reports(id) {
+		if w.status[id] != "stopped" {
+			w.emit(WatchEvent{Type: "exit", ID: id, Timestamp: time.Now().UTC()})
+		}
+		w.emit(WatchEvent{Type: "delete", ID: id, Timestamp: time.Now().UTC()})
+	}
+	delete(w.status, id)
+	delete(w.oomCount, id)
+}
+
+// Run drains inotify events and a periodic stats ticker until the
+// underlying inotify fd is closed, posting every observed transition
+// and resource sample to publish.
+func (w *Watcher) Run(publish func(WatchEvent)) {
+	w.publish = publish
+
+	raw := make(chan []rawInotifyEvent)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			events, err := w.in.read()
+			if err != nil {
+				errs <- err
+				return
+			}
+			raw <- events
+		}
+	}()
+
+	ticker := time.NewTicker(w.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case events := <-raw:
+			for _, ev := range events {
+				target, ok := w.targets[ev.wd]
+				if !ok {
+					continue
+				}
+				switch target.kind {
+				case watchRoot:
+					w.handleRootEvent(ev)
+				case watchState:
+					w.handleStateEvent(target.id)
+				case watchOOM:
+					w.handleOOMEvent(target.id)
+				}
+			}
+		case <-ticker.C:
+			w.sampleAll()
+		case <-errs:
+			return
+		}
+	}
+}
+
+// sampleAll posts a "stats" event for every container the watcher is
+// interested in, independent of whether its state has changed, so an
+// idle running container still reports periodic resource usage.
+func (w *Watcher) sampleAll() {
+	for id := range w.status {
+		if !w.reports(id) {
+			continue
+		}
+		w.postStats(id)
+	}
+}
+
+func (w *Watcher) postStats(id string) {
+	container, err := w.factory.Load(id)
+	if err != nil {
+		return
+	}
+	state, err := container.State()
+	if err != nil {
+		return
+	}
+	w.emit(WatchEvent{Type: "stats", ID: id, Timestamp: time.Now().UTC(), Data: sampleUsage(state.CgroupPaths)})
+}
+
+func (w *Watcher) handleRootEvent(ev rawInotifyEvent) {
+	id := ev.name
+	if id == "" {
+		return
+	}
+	switch {
+	case ev.mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+		if info, err := os.Stat(filepath.Join(w.root, id)); err == nil && info.IsDir() {
+			w.track(id, true)
+		}
+	case ev.mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0:
+		if _, tracked := w.status[id]; tracked {
+			w.untrack(id)
+		}
+	}
+}
+
+// handleStateEvent re-reads a container's status after its state.json
+// changed, translating the transition into the matching OCI lifecycle
+// event and posting a stats sample alongside it. Note that "exec"
+// (running an additional process in an already-running container)
+// doesn't change Status and so can't be observed this way; the daemon's
+// Exec RPC posts its own "exec" event directly instead.
+func (w *Watcher) handleStateEvent(id string) {
+	container, err := w.factory.Load(id)
+	if err != nil {
+		return
+	}
+	status, err := container.Status()
+	if err != nil {
+		return
+	}
+
+	prev := w.status[id]
+	cur := status.String()
+	w.status[id] = cur
+
+	if w.reports(id) {
+		if evType, ok := lifecycleEvent(prev, cur); ok {
+			w.emit(WatchEvent{Type: evType, ID: id, Timestamp: time.Now().UTC()})
+		}
+		w.postStats(id)
+	}
+}
+
+// lifecycleEvent maps a status transition to the OCI lifecycle verb it
+// represents. ok is false for transient statuses (pausing, stopping) or
+// a transition we don't report on, and when prev equals cur.
+func lifecycleEvent(prev, cur string) (string, bool) {
+	if prev == cur {
+		return "", false
+	}
+	switch cur {
+	case "running":
+		if prev == "paused" {
+			return "resume", true
+		}
+		return "start", true
+	case "paused":
+		return "pause", true
+	case "stopped":
+		return "exit", true
+	default:
+		return "", false
+	}
+}
+
+func (w *Watcher) handleOOMEvent(id string) {
+	if !w.reports(id) {
+		return
+	}
+	container, err := w.factory.Load(id)
+	if err != nil {
+		return
+	}
+	state, err := container.State()
+	if err != nil {
+		return
+	}
+	memoryPath := state.CgroupPaths["memory"]
+	if memoryPath == "" {
+		return
+	}
+	for _, name := range []string{"memory.events", "memory.oom_control"} {
+		data, err := ioutil.ReadFile(filepath.Join(memoryPath, name))
+		if err != nil {
+			continue
+		}
+		count, ok := parseOOMCount(string(data))
+		if !ok {
+			continue
+		}
+		if count > w.oomCount[id] {
+			w.emit(WatchEvent{Type: "oom", ID: id, Timestamp: time.Now().UTC()})
+		}
+		w.oomCount[id] = count
+		return
+	}
+}
+
+// parseOOMCount extracts the oom/oom_kill counter from the contents of a
+// memory.events or memory.oom_control file.
+func parseOOMCount(contents string) (uint64, bool) {
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if fields[0] != "oom" && fields[0] != "oom_kill" {
+			continue
+		}
+		count, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		return count, true
+	}
+	return 0, false
+}
+
+// resourceUsage is the periodic cgroup sample posted alongside a
+// "stats" event.
+type resourceUsage struct {
+	MemoryUsageBytes uint64 `json:"memory_usage_bytes"`
+	CPUUsageNanos    uint64 `json:"cpu_usage_nanos"`
+}
+
+// sampleUsage reads the current memory and cpu accounting for a
+// container from its cgroup, leaving a field zero when it can't be read.
+// Memory is read from memory.current (cgroup v2) or memory.usage_in_bytes
+// (cgroup v1); cpu is read only from the cgroup v1 cpuacct.usage file, so
+// CPUUsageNanos stays zero under cgroup v2.
+func sampleUsage(cgroupPaths map[string]string) resourceUsage {
+	var u resourceUsage
+	if path := cgroupPaths["memory"]; path != "" {
+		if v, ok := readCgroupUint(path, "memory.current"); ok {
+			u.MemoryUsageBytes = v
+		} else if v, ok := readCgroupUint(path, "memory.usage_in_bytes"); ok {
+			u.MemoryUsageBytes = v
+		}
+	}
+	if path := cgroupPaths["cpuacct"]; path != "" {
+		if v, ok := readCgroupUint(path, "cpuacct.usage"); ok {
+			u.CPUUsageNanos = v
+		}
+	} else if path := cgroupPaths["cpu"]; path != "" {
+		if v, ok := readCgroupUint(path, "cpuacct.usage"); ok {
+			u.CPUUsageNanos = v
+		}
+	}
+	return u
+}
+
+func readCgroupUint(dir, name string) (uint64, bool) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// inotify is a small wrapper around the inotify(7) syscalls used to
+// drive Watcher without polling.
+type inotify struct {
+	fd int
+}
+
+func newInotify() (*inotify, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	return &inotify{fd: fd}, nil
+}
+
+func (n *inotify) add(path string, mask uint32) (int32, error) {
+	wd, err := unix.InotifyAddWatch(n.fd, path, mask)
+	if err != nil {
+		return 0, err
+	}
+	return int32(wd), nil
+}
+
+func (n *inotify) remove(wd int32) {
+	unix.InotifyRmWatch(n.fd, uint32(wd))
+}
+
+func (n *inotify) close() error {
+	return syscall.Close(n.fd)
+}
+
+type rawInotifyEvent struct {
+	wd   int32
+	mask uint32
+	name string
+}
+
+var inotifyEventSize = int(unsafe.Sizeof(unix.InotifyEvent{}))
+
+// read blocks until at least one inotify event is available and returns
+// everything currently buffered.
+func (n *inotify) read() ([]rawInotifyEvent, error) {
+	var buf [4096]byte
+	count, err := syscall.Read(n.fd, buf[:])
+	if err != nil {
+		return nil, err
+	}
+	var events []rawInotifyEvent
+	offset := 0
+	for offset+inotifyEventSize <= count {
+		rawEv := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + inotifyEventSize
+		nameEnd := nameStart + int(rawEv.Len)
+		var name string
+		if rawEv.Len > 0 && nameEnd <= count {
+			name = strings.TrimRight(string(buf[nameStart:nameEnd]), "\x00")
+		}
+		events = append(events, rawInotifyEvent{wd: rawEv.Wd, mask: rawEv.Mask, name: name})
+		offset = nameEnd
+	}
+	return events, nil
+}
@@ -0,0 +1,76 @@
+// +build linux
+
+package service
+
+import "testing"
+
+func TestParseOOMCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     uint64
+		wantOK   bool
+	}{
+		{
+			name:     "cgroup v2 memory.events",
+			contents: "low 0\nhigh 0\nmax 0\noom 3\noom_kill 3\n",
+			want:     3,
+			wantOK:   true,
+		},
+		{
+			name:     "cgroup v1 memory.oom_control",
+			contents: "oom_kill_disable 0\nunder_oom 0\noom_kill 7\n",
+			want:     7,
+			wantOK:   true,
+		},
+		{
+			name:     "no oom counter present",
+			contents: "low 0\nhigh 0\n",
+			want:     0,
+			wantOK:   false,
+		},
+		{
+			name:     "malformed line is skipped",
+			contents: "oom not-a-number\noom_kill 2\n",
+			want:     2,
+			wantOK:   true,
+		},
+		{
+			name:     "empty file",
+			contents: "",
+			want:     0,
+			wantOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseOOMCount(tt.contents)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseOOMCount(%q) = (%d, %v), want (%d, %v)", tt.contents, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLifecycleEvent(t *testing.T) {
+	tests := []struct {
+		prev, cur string
+		wantType  string
+		wantOK    bool
+	}{
+		{prev: "created", cur: "running", wantType: "start", wantOK: true},
+		{prev: "paused", cur: "running", wantType: "resume", wantOK: true},
+		{prev: "running", cur: "paused", wantType: "pause", wantOK: true},
+		{prev: "running", cur: "stopped", wantType: "exit", wantOK: true},
+		{prev: "running", cur: "pausing", wantType: "", wantOK: false},
+		{prev: "paused", cur: "stopping", wantType: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := lifecycleEvent(tt.prev, tt.cur)
+		if ok != tt.wantOK || got != tt.wantType {
+			t.Errorf("lifecycleEvent(%q, %q) = (%q, %v), want (%q, %v)", tt.prev, tt.cur, got, ok, tt.wantType, tt.wantOK)
+		}
+	}
+}
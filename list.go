@@ -3,20 +3,23 @@
 package main
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
-	"encoding/json"
-
 	"github.com/codegangsta/cli"
-	"github.com/opencontainers/runc/libcontainer/utils"
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/service"
 )
 
-const formatOptions = `table or json`
+const formatOptions = `table, json, csv, or template=<go template>`
 
 // containerState represents the platform agnostic pieces relating to a
 // running container's status and state
@@ -31,8 +34,98 @@ type containerState struct {
 	Bundle string `json:"bundle"`
 	// Created is the unix timestamp for the creation time of the container in UTC
 	Created time.Time `json:"created"`
+	// Owner is the uid that owns the container's state directory, or the
+	// mapped root uid inside the container's user namespace when one is
+	// configured
+	Owner string `json:"owner"`
+}
+
+// lazyState loads a container's full libcontainer state on first use, so
+// columns that don't need it (id, pid, status, bundle, created, age)
+// never pay for the extra factory.Load.
+type lazyState struct {
+	factory libcontainer.Factory
+	id      string
+	loaded  bool
+	state   *libcontainer.State
+	err     error
+}
+
+func (l *lazyState) get() (*libcontainer.State, error) {
+	if !l.loaded {
+		l.loaded = true
+		container, err := l.factory.Load(l.id)
+		if err != nil {
+			l.err = err
+		} else {
+			l.state, l.err = container.State()
+		}
+	}
+	return l.state, l.err
+}
+
+// columnCtx carries the per-row state a column's value func may need
+// beyond the containerState summary: the lazily-loaded full state, and
+// whether values should be truncated for display (table/csv) or left
+// intact (json/template).
+type columnCtx struct {
+	full     *lazyState
+	truncate bool
+}
+
+// column describes a single field of `runc list` output: a header to
+// print in table mode and a function to derive its value for a given
+// container. Columns that need more than the containerState summary
+// (rootfs, cgroup) read through ctx.full so unused columns cost
+// nothing.
+type column struct {
+	header string
+	value  func(item containerState, ctx columnCtx) string
 }
 
+var columns = map[string]column{
+	"id": {"ID", func(item containerState, ctx columnCtx) string {
+		if ctx.truncate && len(item.ID) > 12 {
+			return item.ID[:12]
+		}
+		return item.ID
+	}},
+	"pid": {"PID", func(item containerState, _ columnCtx) string {
+		return strconv.Itoa(item.InitProcessPid)
+	}},
+	"status": {"STATUS", func(item containerState, _ columnCtx) string {
+		return item.Status
+	}},
+	"bundle": {"BUNDLE", func(item containerState, _ columnCtx) string {
+		return item.Bundle
+	}},
+	"created": {"CREATED", func(item containerState, _ columnCtx) string {
+		return item.Created.Format(time.RFC3339Nano)
+	}},
+	"age": {"AGE", func(item containerState, _ columnCtx) string {
+		return time.Since(item.Created).Round(time.Second).String()
+	}},
+	"owner": {"OWNER", func(item containerState, _ columnCtx) string {
+		return item.Owner
+	}},
+	"rootfs": {"ROOTFS", func(item containerState, ctx columnCtx) string {
+		state, err := ctx.full.get()
+		if err != nil {
+			return ""
+		}
+		return state.Config.Rootfs
+	}},
+	"cgroup": {"CGROUP", func(item containerState, ctx columnCtx) string {
+		state, err := ctx.full.get()
+		if err != nil {
+			return ""
+		}
+		return state.CgroupPaths["cpu"]
+	}},
+}
+
+var defaultColumns = []string{"id", "pid", "status", "bundle", "created", "owner"}
+
 var listCommand = cli.Command{
 	Name:  "list",
 	Usage: "lists containers started by runc with the given root",
@@ -49,7 +142,27 @@ in json format:
 		},
 		cli.BoolFlag{
 			Name:  "quiet, q",
-			Usage: "display only container IDs",
+			Usage: "display only container IDs, one full ID per line with no header; --columns, --sort and --format are ignored",
+		},
+		cli.StringFlag{
+			Name:  "columns",
+			Usage: "comma separated list of columns to display, e.g. id,pid,status,bundle,created,owner,rootfs,cgroup,age",
+		},
+		cli.StringFlag{
+			Name:  "sort",
+			Usage: "comma separated list of columns to sort by",
+		},
+		cli.BoolFlag{
+			Name:  "reverse",
+			Usage: "reverse the sort order",
+		},
+		cli.BoolFlag{
+			Name:  "no-trunc",
+			Usage: "don't truncate the ID column",
+		},
+		cli.StringFlag{
+			Name:  "filter",
+			Usage: "comma separated list of column=value pairs to filter by, e.g. status=running,bundle=/var/lib/containers/foo",
 		},
 	},
 	Action: func(context *cli.Context) error {
@@ -58,6 +171,15 @@ in json format:
 			return err
 		}
 
+		factory, err := loadFactory(context)
+		if err != nil {
+			return err
+		}
+		s, err = filterContainers(context, factory, s)
+		if err != nil {
+			return err
+		}
+
 		if context.Bool("quiet") {
 			for _, item := range s {
 				fmt.Println(item.ID)
@@ -65,25 +187,67 @@ in json format:
 			return nil
 		}
 
-		switch context.String("format") {
-		case "", "table":
+		names := defaultColumns
+		if raw := context.String("columns"); raw != "" {
+			names = strings.Split(raw, ",")
+		}
+		for _, name := range names {
+			if _, ok := columns[name]; !ok {
+				return fmt.Errorf("invalid column: %s", name)
+			}
+		}
+
+		if err := sortContainers(context, factory, s); err != nil {
+			return err
+		}
+
+		truncate := !context.Bool("no-trunc")
+		format := context.String("format")
+		switch {
+		case format == "" || format == "table":
 			w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
-			fmt.Fprint(w, "ID\tPID\tSTATUS\tBUNDLE\tCREATED\n")
+			headers := make([]string, len(names))
+			for i, name := range names {
+				headers[i] = columns[name].header
+			}
+			fmt.Fprintln(w, strings.Join(headers, "\t"))
 			for _, item := range s {
-				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
-					item.ID,
-					item.InitProcessPid,
-					item.Status,
-					item.Bundle,
-					item.Created.Format(time.RFC3339Nano))
+				fmt.Fprintln(w, strings.Join(columnValues(item, factory, names, truncate), "\t"))
 			}
 			if err := w.Flush(); err != nil {
 				return err
 			}
-		case "json":
+		case format == "json":
 			if err := json.NewEncoder(os.Stdout).Encode(s); err != nil {
 				return err
 			}
+		case format == "csv":
+			cw := csv.NewWriter(os.Stdout)
+			headers := make([]string, len(names))
+			for i, name := range names {
+				headers[i] = columns[name].header
+			}
+			if err := cw.Write(headers); err != nil {
+				return err
+			}
+			for _, item := range s {
+				if err := cw.Write(columnValues(item, factory, names, truncate)); err != nil {
+					return err
+				}
+			}
+			cw.Flush()
+			return cw.Error()
+		case strings.HasPrefix(format, "template="):
+			tmpl, err := template.New("list").Parse(strings.TrimPrefix(format, "template="))
+			if err != nil {
+				return err
+			}
+			for _, item := range s {
+				if err := tmpl.Execute(os.Stdout, item); err != nil {
+					return err
+				}
+				fmt.Fprintln(os.Stdout)
+			}
 		default:
 			return fmt.Errorf("invalid format option")
 		}
@@ -91,42 +255,164 @@ in json format:
 	},
 }
 
-func getContainers(context *cli.Context) ([]containerState, error) {
-	factory, err := loadFactory(context)
+// columnValues renders the named columns for a single container.
+func columnValues(item containerState, factory libcontainer.Factory, names []string, truncate bool) []string {
+	ctx := columnCtx{full: &lazyState{factory: factory, id: item.ID}, truncate: truncate}
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = columns[name].value(item, ctx)
+	}
+	return values
+}
+
+// filterContainers drops containers that don't match the --filter flag,
+// a comma separated list of column=value pairs. Multiple values for the
+// same column are OR'd together; distinct columns are AND'd.
+func filterContainers(context *cli.Context, factory libcontainer.Factory, items []containerState) ([]containerState, error) {
+	raw := context.String("filter")
+	if raw == "" {
+		return items, nil
+	}
+	want, err := parseFilterSpec(raw)
 	if err != nil {
 		return nil, err
 	}
-	root := context.GlobalString("root")
-	absRoot, err := filepath.Abs(root)
+
+	var out []containerState
+	for _, item := range items {
+		ctx := columnCtx{full: &lazyState{factory: factory, id: item.ID}}
+		if matchesFilters(item, ctx, want) {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// parseFilterSpec parses the --filter flag's comma separated list of
+// column=value clauses into a column -> OR'd values map.
+func parseFilterSpec(raw string) (map[string][]string, error) {
+	want := make(map[string][]string)
+	for _, clause := range strings.Split(raw, ",") {
+		parts := strings.SplitN(clause, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid filter: %s", clause)
+		}
+		field, value := parts[0], parts[1]
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("invalid filter field: %s", field)
+		}
+		want[field] = append(want[field], value)
+	}
+	return want, nil
+}
+
+func matchesFilters(item containerState, ctx columnCtx, want map[string][]string) bool {
+	for field, values := range want {
+		got := columns[field].value(item, ctx)
+		matched := false
+		for _, v := range values {
+			if got == v {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// sortLess reports whether a sorts before b on a single column. Columns with a natural typed ordering are compared as
+// such rather than as their formatted string, so e.g. --sort pid orders
+// 2 before 10. "created" ascends from oldest to newest (the smaller
+// timestamp first); "age", being the inverse of created (a bigger age
+// means an older, i.e. smaller, timestamp), ascends from youngest to
+// oldest, so --sort age puts the most recently created container
+// first. Every other column falls back to comparing its rendered
+// string value.
+func sortLess(field string, factory libcontainer.Factory, a, b containerState) bool {
+	switch field {
+	case "pid":
+		return a.InitProcessPid < b.InitProcessPid
+	case "created":
+		return a.Created.Before(b.Created)
+	case "age":
+		return b.Created.Before(a.Created)
+	default:
+		actx := columnCtx{full: &lazyState{factory: factory, id: a.ID}}
+		bctx := columnCtx{full: &lazyState{factory: factory, id: b.ID}}
+		return columns[field].value(a, actx) < columns[field].value(b, bctx)
+	}
+}
+
+func sortEqual(field string, factory libcontainer.Factory, a, b containerState) bool {
+	switch field {
+	case "pid":
+		return a.InitProcessPid == b.InitProcessPid
+	case "created", "age":
+		return a.Created.Equal(b.Created)
+	default:
+		actx := columnCtx{full: &lazyState{factory: factory, id: a.ID}}
+		bctx := columnCtx{full: &lazyState{factory: factory, id: b.ID}}
+		return columns[field].value(a, actx) == columns[field].value(b, bctx)
+	}
+}
+
+// sortContainers orders items in place according to the --sort flag
+// (a comma separated list of column names, used as successive
+// tiebreakers) and --reverse. It is a no-op when --sort is unset.
+func sortContainers(context *cli.Context, factory libcontainer.Factory, items []containerState) error {
+	raw := context.String("sort")
+	if raw == "" {
+		return nil
+	}
+	return sortBy(items, strings.Split(raw, ","), context.Bool("reverse"), factory)
+}
+
+// sortBy is the cli.Context-free core of sortContainers, split out so
+// the ordering logic can be unit tested directly. factory is only used
+// by columns that need the full state (rootfs, cgroup).
+func sortBy(items []containerState, fields []string, reverse bool, factory libcontainer.Factory) error {
+	for _, f := range fields {
+		if _, ok := columns[f]; !ok {
+			return fmt.Errorf("invalid sort field: %s", f)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, f := range fields {
+			if sortEqual(f, factory, items[i], items[j]) {
+				continue
+			}
+			if reverse {
+				return sortLess(f, factory, items[j], items[i])
+			}
+			return sortLess(f, factory, items[i], items[j])
+		}
+		return false
+	})
+	return nil
+}
+
+func getContainers(context *cli.Context) ([]containerState, error) {
+	factory, err := loadFactory(context)
 	if err != nil {
 		return nil, err
 	}
-	list, err := ioutil.ReadDir(absRoot)
+	infos, err := service.Enumerate(factory, context.GlobalString("root"))
 	if err != nil {
 		fatal(err)
 	}
 
-	var s []containerState
-	for _, item := range list {
-		if item.IsDir() {
-			container, err := factory.Load(item.Name())
-			if err != nil {
-				return nil, err
-			}
-			containerStatus, err := container.Status()
-			if err != nil {
-				return nil, err
-			}
-			state, err := container.State()
-			if err != nil {
-				return nil, err
-			}
-			s = append(s, containerState{
-				ID:             state.BaseState.ID,
-				InitProcessPid: state.BaseState.InitProcessPid,
-				Status:         containerStatus.String(),
-				Bundle:         utils.SearchLabels(state.Config.Labels, "bundle"),
-				Created:        state.BaseState.Created})
+	s := make([]containerState, len(infos))
+	for i, info := range infos {
+		s[i] = containerState{
+			ID:             info.ID,
+			InitProcessPid: info.InitProcessPid,
+			Status:         info.Status,
+			Bundle:         info.Bundle,
+			Created:        info.Created,
+			Owner:          info.Owner,
 		}
 	}
 	return s, nil
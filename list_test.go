@@ -0,0 +1,188 @@
+// +build linux
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumnValues(t *testing.T) {
+	item := containerState{
+		ID:             "abcdefabcdefabcdefabcdef",
+		InitProcessPid: 42,
+		Status:         "running",
+		Bundle:         "/var/lib/containers/foo",
+		Created:        time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC),
+		Owner:          "1000",
+	}
+
+	tests := []struct {
+		name     string
+		names    []string
+		truncate bool
+		want     []string
+	}{
+		{
+			name:     "subset of default columns, truncated id",
+			names:    []string{"id", "pid", "status", "owner"},
+			truncate: true,
+			want:     []string{"abcdefabcdef", "42", "running", "1000"},
+		},
+		{
+			name:     "no-trunc keeps the full id",
+			names:    []string{"id"},
+			truncate: false,
+			want:     []string{item.ID},
+		},
+		{
+			name:     "created renders RFC3339Nano",
+			names:    []string{"created"},
+			truncate: false,
+			want:     []string{item.Created.Format(time.RFC3339Nano)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := columnValues(item, nil, tt.names, tt.truncate)
+			if len(got) != len(tt.want) {
+				t.Fatalf("columnValues() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("columnValues()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSortContainersNumeric(t *testing.T) {
+	items := []containerState{
+		{ID: "a", InitProcessPid: 10},
+		{ID: "b", InitProcessPid: 2},
+		{ID: "c", InitProcessPid: 30},
+	}
+
+	if err := sortBy(items, []string{"pid"}, false, nil); err != nil {
+		t.Fatalf("sortBy: %v", err)
+	}
+	want := []string{"b", "a", "c"}
+	for i, w := range want {
+		if items[i].ID != w {
+			t.Errorf("sort by pid: items[%d].ID = %q, want %q (got order %v)", i, items[i].ID, w, ids(items))
+		}
+	}
+}
+
+func TestSortContainersAge(t *testing.T) {
+	now := time.Date(2026, 7, 26, 0, 0, 0, 0, time.UTC)
+	items := []containerState{
+		{ID: "older", Created: now.Add(-2 * time.Minute)},
+		{ID: "newer", Created: now.Add(-10 * time.Second)},
+	}
+
+	if err := sortBy(items, []string{"age"}, false, nil); err != nil {
+		t.Fatalf("sortBy: %v", err)
+	}
+	// Ascending "age" puts the youngest (smallest age, most recently
+	// created) container first: comparing Created ascending (like
+	// "created" does) would put the oldest container first instead.
+	if items[0].ID != "newer" || items[1].ID != "older" {
+		t.Errorf("sort by age = %v, want [newer older]", ids(items))
+	}
+}
+
+func TestSortContainersReverse(t *testing.T) {
+	items := []containerState{
+		{ID: "a", InitProcessPid: 1},
+		{ID: "b", InitProcessPid: 3},
+		{ID: "c", InitProcessPid: 2},
+	}
+
+	if err := sortBy(items, []string{"pid"}, true, nil); err != nil {
+		t.Fatalf("sortBy: %v", err)
+	}
+	want := []string{"b", "c", "a"}
+	for i, w := range want {
+		if items[i].ID != w {
+			t.Errorf("reverse sort by pid: items[%d].ID = %q, want %q (got order %v)", i, items[i].ID, w, ids(items))
+		}
+	}
+}
+
+func TestSortContainersInvalidField(t *testing.T) {
+	items := []containerState{{ID: "a"}}
+	if err := sortBy(items, []string{"bogus"}, false, nil); err == nil {
+		t.Error("sortBy with an unknown column should return an error")
+	}
+}
+
+func ids(items []containerState) []string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = item.ID
+	}
+	return out
+}
+
+func TestParseFilterSpec(t *testing.T) {
+	got, err := parseFilterSpec("status=running,status=paused,bundle=/var/lib/containers/foo")
+	if err != nil {
+		t.Fatalf("parseFilterSpec: %v", err)
+	}
+	want := map[string][]string{
+		"status": {"running", "paused"},
+		"bundle": {"/var/lib/containers/foo"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseFilterSpec() = %v, want %v", got, want)
+	}
+	for field, values := range want {
+		if strings.Join(got[field], ",") != strings.Join(values, ",") {
+			t.Errorf("parseFilterSpec()[%q] = %v, want %v", field, got[field], values)
+		}
+	}
+}
+
+func TestParseFilterSpecErrors(t *testing.T) {
+	tests := []string{
+		"status",                // missing "=value"
+		"nosuchcolumn=running",  // unknown column
+	}
+	for _, raw := range tests {
+		if _, err := parseFilterSpec(raw); err == nil {
+			t.Errorf("parseFilterSpec(%q) expected an error, got nil", raw)
+		}
+	}
+}
+
+func TestMatchesFilters(t *testing.T) {
+	running := containerState{ID: "a", Status: "running", Bundle: "/foo"}
+	paused := containerState{ID: "b", Status: "paused", Bundle: "/foo"}
+	stopped := containerState{ID: "c", Status: "stopped", Bundle: "/bar"}
+
+	tests := []struct {
+		name string
+		item containerState
+		want map[string][]string
+		ok   bool
+	}{
+		{name: "single column match", item: running, want: map[string][]string{"status": {"running"}}, ok: true},
+		{name: "single column no match", item: stopped, want: map[string][]string{"status": {"running"}}, ok: false},
+		{name: "OR within a column", item: paused, want: map[string][]string{"status": {"running", "paused"}}, ok: true},
+		{name: "AND across columns, both match", item: running, want: map[string][]string{"status": {"running"}, "bundle": {"/foo"}}, ok: true},
+		{name: "AND across columns, one fails", item: stopped, want: map[string][]string{"status": {"stopped"}, "bundle": {"/foo"}}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := columnCtx{}
+			if got := matchesFilters(tt.item, ctx, tt.want); got != tt.ok {
+				t.Errorf("matchesFilters(%+v, %v) = %v, want %v", tt.item, tt.want, got, tt.ok)
+			}
+		})
+	}
+}